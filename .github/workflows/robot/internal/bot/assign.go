@@ -20,33 +20,30 @@ import (
 	"context"
 	"log"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/gravitational/trace"
 )
 
+// ReviewerStrategy selects the set of reviewers to request for the PR
+// described by the bot's Environment. Strategies are composable: the
+// backport special case, for example, wraps whichever strategy the config
+// picked as its fallback for PRs that aren't backports.
+type ReviewerStrategy interface {
+	// Reviewers returns the GitHub usernames that should review the
+	// current PR.
+	Reviewers(ctx context.Context, b *Bot) ([]string, error)
+}
+
 // Assign will assign reviewers for this PR.
 //
 // Assign works by parsing the PR, discovering the changes, and returning a
 // set of reviewers determined by: content of the PR, if the author is internal
 // or external, and team they are on.
 func (b *Bot) Assign(ctx context.Context) error {
-	var err error
-	var reviewers []string
-
-	switch {
-	// If this PR is a backport PR try and assign original reviewers. If the
-	// original reviewers can not be found, then put it through the normal
-	// review process.
-	case isBackport(b.c.Environment.UnsafeBase):
-		reviewers, err = b.getBackportReviewers(ctx)
-		if err != nil {
-			reviewers, err = b.getReviewers(ctx)
-		}
-	default:
-		reviewers, err := b.getReviewers(ctx)
-	}
+	reviewers, err := b.reviewerStrategy().Reviewers(ctx, b)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -66,7 +63,46 @@ func (b *Bot) Assign(ctx context.Context) error {
 	return nil
 }
 
-func (b *Bot) getReviewers(ctx context.Context) ([]string, error) {
+// reviewerStrategy returns the ReviewerStrategy configured for this bot. A
+// backport PR always tries the original PR's reviewers first, falling back
+// to whichever strategy the config picked (CODEOWNERS-style by default).
+func (b *Bot) reviewerStrategy() ReviewerStrategy {
+	fallback := b.c.ReviewerStrategy
+	if fallback == nil {
+		fallback = codeownersStrategy{}
+	}
+	return backportStrategy{fallback: fallback}
+}
+
+// NewReviewerStrategy builds the ReviewerStrategy named by strategy, so a
+// bot's config only has to carry a string rather than construct a
+// ReviewerStrategy itself. The result is meant to be assigned to
+// Config.ReviewerStrategy.
+func NewReviewerStrategy(strategy string, pool TeamReviewPool, legalTeam []string) (ReviewerStrategy, error) {
+	switch strategy {
+	case "", "codeowners":
+		return codeownersStrategy{}, nil
+	case "load-balanced":
+		if pool == nil {
+			return nil, trace.BadParameter("load-balanced reviewer strategy requires a TeamReviewPool")
+		}
+		return loadBalancedStrategy{pool: pool}, nil
+	case "license-sensitive":
+		if len(legalTeam) == 0 {
+			return nil, trace.BadParameter("license-sensitive reviewer strategy requires a legal review team")
+		}
+		return licenseSensitiveStrategy{inner: codeownersStrategy{}, legalTeam: legalTeam}, nil
+	default:
+		return nil, trace.BadParameter("unknown reviewer strategy %q", strategy)
+	}
+}
+
+// codeownersStrategy is the original, CODEOWNERS-style policy: reviewers
+// are picked from the docs/code teams based on which paths the PR touches
+// and whether the author is internal or external.
+type codeownersStrategy struct{}
+
+func (codeownersStrategy) Reviewers(ctx context.Context, b *Bot) ([]string, error) {
 	docs, code, err := b.parseChanges(ctx)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -75,6 +111,229 @@ func (b *Bot) getReviewers(ctx context.Context) ([]string, error) {
 	return b.c.Review.Get(b.c.Environment.Author, docs, code), nil
 }
 
+// backportStrategy tries to reassign the reviewers who approved the
+// original PR a backport was cut from, falling back to fallback if the
+// original can't be found (or this isn't a backport at all).
+type backportStrategy struct {
+	fallback ReviewerStrategy
+}
+
+func (s backportStrategy) Reviewers(ctx context.Context, b *Bot) ([]string, error) {
+	if !isBackport(b.c.Environment.UnsafeBase) {
+		return s.fallback.Reviewers(ctx, b)
+	}
+
+	reviewers, err := b.getBackportReviewers(ctx)
+	if err != nil {
+		return s.fallback.Reviewers(ctx, b)
+	}
+	return reviewers, nil
+}
+
+// TeamReviewPool is implemented by a review policy that can enumerate, per
+// required team, the candidate reviewers for a PR touching the given paths.
+// loadBalancedStrategy uses it to pick the least-loaded candidate rather
+// than whichever one CODEOWNERS-style ordering happens to put first.
+type TeamReviewPool interface {
+	CandidatesByTeam(author string, docs bool, code bool) map[string][]string
+}
+
+// loadBalancedStrategy picks, per required team, the candidate with the
+// fewest open review requests instead of a fixed CODEOWNERS ordering, so
+// review load doesn't pile up on whoever is listed first.
+type loadBalancedStrategy struct {
+	pool TeamReviewPool
+}
+
+func (s loadBalancedStrategy) Reviewers(ctx context.Context, b *Bot) ([]string, error) {
+	docs, code, err := b.parseChanges(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	candidatesByTeam := s.pool.CandidatesByTeam(b.c.Environment.Author, docs, code)
+
+	teams := make([]string, 0, len(candidatesByTeam))
+	for team := range candidatesByTeam {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+
+	var reviewers []string
+	for _, team := range teams {
+		reviewer, err := s.leastLoaded(ctx, b, candidatesByTeam[team])
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		reviewers = append(reviewers, reviewer)
+	}
+
+	return reviewers, nil
+}
+
+// leastLoaded returns whichever of candidates currently has the fewest open
+// review requests assigned to them.
+func (s loadBalancedStrategy) leastLoaded(ctx context.Context, b *Bot, candidates []string) (string, error) {
+	if len(candidates) == 0 {
+		return "", trace.BadParameter("no candidate reviewers for team")
+	}
+
+	best := candidates[0]
+	bestLoad := -1
+
+	for _, candidate := range candidates {
+		load, err := b.c.GitHub.OpenReviewCount(ctx,
+			b.c.Environment.Organization,
+			b.c.Environment.Repository,
+			candidate)
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+
+		if bestLoad == -1 || load < bestLoad {
+			best = candidate
+			bestLoad = load
+		}
+	}
+
+	return best, nil
+}
+
+// licenseSensitiveStrategy wraps another strategy and adds a reviewer from
+// legalTeam whenever the PR introduces a new third-party file under a
+// copyleft or unrecognized license.
+type licenseSensitiveStrategy struct {
+	inner     ReviewerStrategy
+	legalTeam []string
+}
+
+func (s licenseSensitiveStrategy) Reviewers(ctx context.Context, b *Bot) ([]string, error) {
+	reviewers, err := s.inner.Reviewers(ctx, b)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	files, err := b.c.GitHub.ListFiles(ctx,
+		b.c.Environment.Organization,
+		b.c.Environment.Repository,
+		b.c.Environment.Number)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	for _, file := range files {
+		if !file.IsNew || !isThirdParty(file.Name) {
+			continue
+		}
+
+		match := classifyLicense(file.Content)
+		if match.copyleftOrUnknown() {
+			log.Printf("Assign: %v has a %v license, requesting legal review.", file.Name, match.name)
+			return append(reviewers, s.legalTeam...), nil
+		}
+	}
+
+	return reviewers, nil
+}
+
+// isThirdParty reports whether path looks like vendored/third-party code
+// rather than code owned by this repo.
+func isThirdParty(path string) bool {
+	return strings.Contains(path, "/vendor/") || strings.Contains(path, "/third_party/")
+}
+
+// licenseMatch is the result of classifying a file's license header.
+type licenseMatch struct {
+	name     string
+	coverage float64
+}
+
+func (m licenseMatch) copyleftOrUnknown() bool {
+	if m.coverage < licenseMatchThreshold {
+		return true
+	}
+	switch m.name {
+	case "GPL-2.0", "GPL-3.0", "AGPL-3.0":
+		return true
+	}
+	return false
+}
+
+// licenseMatchThreshold is the minimum fraction of a canonical license
+// template's tokens that must appear, in order, in a file for it to count
+// as a match rather than "unknown".
+const licenseMatchThreshold = 0.9
+
+// canonicalLicenses holds a normalized token prefix of each license this
+// bot can recognize. It's intentionally small: a token-scanning approach
+// like google/licensecheck, not a full SPDX matcher.
+var canonicalLicenses = map[string]string{
+	"MIT":        "permission is hereby granted free of charge to any person obtaining a copy of this software",
+	"BSD-3":      "redistribution and use in source and binary forms with or without modification are permitted provided that the following conditions are met",
+	"Apache-2.0": "licensed under the apache license version 2 0 the license you may not use this file except in compliance with the license",
+	"GPL-2.0":    "this program is free software you can redistribute it and or modify it under the terms of the gnu general public license",
+	"GPL-3.0":    "this program is free software you can redistribute it and or modify it under the terms of the gnu general public license as published by",
+	"AGPL-3.0":   "this program is free software you can redistribute it and or modify it under the terms of the gnu affero general public license as published by",
+}
+
+// classifyLicense compares content's normalized text against each
+// canonical license template and returns the best match.
+func classifyLicense(content string) licenseMatch {
+	normalized := normalizeLicenseText(content)
+
+	best := licenseMatch{name: "unknown"}
+	for name, template := range canonicalLicenses {
+		if coverage := tokenCoverage(normalized, template); coverage > best.coverage {
+			best = licenseMatch{name: name, coverage: coverage}
+		}
+	}
+	return best
+}
+
+// normalizeLicenseText lowercases content and collapses all punctuation and
+// whitespace runs to single spaces, the way google/licensecheck normalizes
+// text before comparing it to canonical templates.
+func normalizeLicenseText(content string) string {
+	var b strings.Builder
+	lastWasSpace := true
+	for _, r := range strings.ToLower(content) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastWasSpace = false
+			continue
+		}
+		if !lastWasSpace {
+			b.WriteRune(' ')
+			lastWasSpace = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// tokenCoverage returns the fraction of template's tokens found, in order,
+// as a subsequence of text's tokens.
+func tokenCoverage(text string, template string) float64 {
+	textTokens := strings.Fields(text)
+	templateTokens := strings.Fields(template)
+	if len(templateTokens) == 0 {
+		return 0
+	}
+
+	matched := 0
+	i := 0
+	for _, token := range textTokens {
+		if i >= len(templateTokens) {
+			break
+		}
+		if token == templateTokens[i] {
+			matched++
+			i++
+		}
+	}
+
+	return float64(matched) / float64(len(templateTokens))
+}
+
 func (b *Bot) getBackportReviewers(ctx context.Context) ([]string, error) {
 	originalNumber, err := b.parseOriginal(ctx,
 		b.c.Environment.Organization,
@@ -101,7 +360,7 @@ func (b *Bot) getBackportReviewers(ctx context.Context) ([]string, error) {
 		reviewers = append(reviewers, review.Author)
 	}
 	if len(reviewers) < 2 {
-		return nil, trace.IsNotFound("invalid")
+		return nil, trace.NotFound("not enough approved reviews on the original PR")
 	}
 
 	return reviewers, nil
@@ -117,17 +376,17 @@ func (b *Bot) parseOriginal(ctx context.Context, organization string, repository
 	}
 
 	// Search inside both the title and body.
-	matches := pattern.FindAllStringSubmatch(pull.Title+pull.Body, -1)
+	matches := pattern.FindAllString(pull.Title+pull.Body, -1)
 	if len(matches) != 1 {
-		return trace.BadParameter("found multiple matches, unable to find original")
+		return 0, trace.BadParameter("found %v matches, unable to find original", len(matches))
 	}
 
-	number, err := strconv.Atoi(matches[0])
+	original, err := strconv.Atoi(strings.TrimPrefix(matches[0], "#"))
 	if err != nil {
-		return trace.Wrap(err)
+		return 0, trace.Wrap(err)
 	}
 
-	return number, nil
+	return original, nil
 }
 
 func isBackport(unsafeBase string) bool {
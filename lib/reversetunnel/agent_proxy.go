@@ -17,6 +17,7 @@ limitations under the License.
 package reversetunnel
 
 import (
+	"context"
 	"strings"
 	"sync"
 
@@ -25,14 +26,17 @@ import (
 
 func NewConnectedProxies() *ConnectedProxies {
 	return &ConnectedProxies{
-		change: make(chan struct{}),
+		subscribers: make(map[int]chan []string),
 	}
 }
 
+// ConnectedProxies tracks the set of proxy IDs currently reachable, and lets
+// callers subscribe to changes instead of polling ProxyIDs.
 type ConnectedProxies struct {
-	ids    []string
-	change chan struct{}
-	mu     sync.RWMutex
+	ids         []string
+	subscribers map[int]chan []string
+	nextID      int
+	mu          sync.RWMutex
 }
 
 func (p *ConnectedProxies) ProxyIDs() []string {
@@ -41,8 +45,52 @@ func (p *ConnectedProxies) ProxyIDs() []string {
 	return p.ids
 }
 
-func (p *ConnectedProxies) WaitForChange() <-chan struct{} {
-	return p.change
+// Subscribe returns a channel that receives the current proxy IDs on
+// subscription (if any are known yet) and again every time they change,
+// along with a function that
+// removes the subscription. The channel is buffered to one coalesced
+// snapshot: a subscriber that's slower than updates arrive sees only the
+// latest set of IDs on its next receive, never a backlog of stale ones, and
+// updateProxyIDs never blocks on it.
+//
+// The subscription is also removed once ctx is done, so callers don't need
+// to thread the unsubscribe function through a defer on every code path.
+// Calling the returned unsubscribe func also stops that watch, so passing a
+// context that's never canceled (e.g. context.Background()) and relying on
+// unsubscribe instead doesn't leak the watching goroutine.
+func (p *ConnectedProxies) Subscribe(ctx context.Context) (<-chan []string, func()) {
+	p.mu.Lock()
+
+	ch := make(chan []string, 1)
+	id := p.nextID
+	p.nextID++
+	p.subscribers[id] = ch
+
+	if len(p.ids) > 0 {
+		ch <- p.ids
+	}
+
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() { close(done) })
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.subscribers, id)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+		case <-done:
+		}
+	}()
+
+	return ch, unsubscribe
 }
 
 func (p *ConnectedProxies) updateProxyIDs(ids []string) {
@@ -55,12 +103,27 @@ func (p *ConnectedProxies) updateProxyIDs(ids []string) {
 
 	p.ids = ids
 
-	go func() {
+	for _, ch := range p.subscribers {
+		notify(ch, ids)
+	}
+}
+
+// notify delivers ids to ch without blocking. If ch already holds a
+// snapshot the subscriber hasn't read yet, that stale snapshot is drained
+// and replaced rather than queued behind it, so a slow subscriber coalesces
+// onto the latest update instead of falling further and further behind.
+func notify(ch chan []string, ids []string) {
+	for {
 		select {
-		case p.change <- struct{}{}:
+		case ch <- ids:
+			return
 		default:
 		}
-	}()
+		select {
+		case <-ch:
+		default:
+		}
+	}
 }
 
 func getIDFromPrincipals(principals []string) (string, bool) {
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reversetunnel
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestConnectedProxiesSubscribeNoGoroutineLeak verifies that repeatedly
+// subscribing and unsubscribing under rapid updates, including subscribers
+// that pass a context that's never canceled, doesn't leak the per-subscriber
+// watcher goroutine started by Subscribe.
+func TestConnectedProxiesSubscribeNoGoroutineLeak(t *testing.T) {
+	p := NewConnectedProxies()
+
+	before := runtime.NumGoroutine()
+
+	var unsubscribes []func()
+	for i := 0; i < 50; i++ {
+		_, unsubscribe := p.Subscribe(context.Background())
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			p.updateProxyIDs([]string{strconv.Itoa(i)})
+		}
+	}()
+	<-done
+
+	for _, unsubscribe := range unsubscribes {
+		unsubscribe()
+	}
+
+	require.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= before+1
+	}, time.Second, 10*time.Millisecond)
+}
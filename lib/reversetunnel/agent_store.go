@@ -16,37 +16,78 @@ limitations under the License.
 
 package reversetunnel
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
+
+// agentHealth tracks the signals used to score an agent for routing and
+// eviction: round trip time observed on keepalives, how many sessions are
+// currently routed through it, how many keepalives have failed in a row,
+// and when it last errored.
+type agentHealth struct {
+	rtt                 time.Duration
+	inflightSessions    int
+	consecutiveFailures int
+	lastError           time.Time
+}
+
+// agentEntry is a single agent tracked by agentStore, along with the proxy
+// it's connected to (used to check membership in ConnectedProxies) and its
+// current health.
+type agentEntry struct {
+	agent   *Agent
+	proxyID string
+	health  agentHealth
+}
+
+// AgentStoreStats is a point-in-time snapshot of agentStore, suitable for
+// exporting as prometheus gauges.
+type AgentStoreStats struct {
+	Count                 int
+	HealthyCount          int
+	TotalInflightSessions int
+}
 
 type agentStore struct {
-	agents []*Agent
-	mu     sync.RWMutex
+	entries []*agentEntry
+	mu      sync.RWMutex
 }
 
 func newAgentStore() *agentStore {
 	return &agentStore{
-		agents: make([]*Agent, 0),
+		entries: make([]*agentEntry, 0),
 	}
 }
 
 func (s *agentStore) len() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.agents)
+	return len(s.entries)
 }
 
-func (s *agentStore) add(agent *Agent) {
+// add starts tracking agent, connected to the proxy identified by proxyID.
+func (s *agentStore) add(agent *Agent, proxyID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.agents = append(s.agents, agent)
+	s.entries = append(s.entries, &agentEntry{agent: agent, proxyID: proxyID})
+}
+
+func (s *agentStore) findEntry(agent *Agent) *agentEntry {
+	for _, entry := range s.entries {
+		if entry.agent == agent {
+			return entry
+		}
+	}
+	return nil
 }
 
 func (s *agentStore) unsafeRemove(agent *Agent) bool {
-	for i := range s.agents {
-		if s.agents[i] != agent {
+	for i, entry := range s.entries {
+		if entry.agent != agent {
 			continue
 		}
-		s.agents = append(s.agents[:i], s.agents[i+1:]...)
+		s.entries = append(s.entries[:i], s.entries[i+1:]...)
 		return true
 	}
 
@@ -59,31 +100,135 @@ func (s *agentStore) remove(agent *Agent) bool {
 	return s.unsafeRemove(agent)
 }
 
-// poplen pops an agent from the store if there are more agents in the store
-// than the the given value. The oldest agent is always returned first.
-func (s *agentStore) poplen(l int) (*Agent, bool) {
+// recordKeepalive updates agent's health following a keepalive RTT
+// measurement, resetting its failure streak.
+func (s *agentStore) recordKeepalive(agent *Agent, rtt time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry := s.findEntry(agent); entry != nil {
+		entry.health.rtt = rtt
+		entry.health.consecutiveFailures = 0
+	}
+}
+
+// recordKeepaliveFailure marks a failed keepalive against agent.
+func (s *agentStore) recordKeepaliveFailure(agent *Agent, failedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry := s.findEntry(agent); entry != nil {
+		entry.health.consecutiveFailures++
+		entry.health.lastError = failedAt
+	}
+}
+
+// setInflightSessions records how many sessions are currently routed
+// through agent.
+func (s *agentStore) setInflightSessions(agent *Agent, count int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if l < 0 || len(s.agents) == 0 {
+	if entry := s.findEntry(agent); entry != nil {
+		entry.health.inflightSessions = count
+	}
+}
+
+// score computes a composite health score for entry; lower is healthier.
+// High RTT, failed keepalives, in-flight load, and no longer being
+// connected to a proxy in connectedProxyIDs all push the score up.
+func score(entry *agentEntry, connectedProxyIDs []string) float64 {
+	s := float64(entry.health.rtt.Milliseconds())
+	s += float64(entry.health.consecutiveFailures) * 1000
+	s += float64(entry.health.inflightSessions) * 10
+
+	if !contains(connectedProxyIDs, entry.proxyID) {
+		s += 100_000
+	}
+
+	return s
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// pickBest returns the healthiest, least-loaded agent for routing a new
+// session, preferring agents connected to a proxy in connectedProxyIDs.
+func (s *agentStore) pickBest(connectedProxyIDs []string) (*Agent, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var best *agentEntry
+	var bestScore float64
+	for _, entry := range s.entries {
+		entryScore := score(entry, connectedProxyIDs)
+		if best == nil || entryScore < bestScore {
+			best = entry
+			bestScore = entryScore
+		}
+	}
+
+	if best == nil {
 		return nil, false
 	}
-	if len(s.agents) <= l {
+	return best.agent, true
+}
+
+// evictWorst removes and returns the unhealthiest agent if the store holds
+// more than l agents. It replaces poplen's "oldest first" policy: a
+// freshly-connected healthy agent no longer gets evicted ahead of a stale
+// one just because it was added later.
+func (s *agentStore) evictWorst(connectedProxyIDs []string, l int) (*Agent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l < 0 || len(s.entries) <= l {
 		return nil, false
 	}
 
-	agent := s.agents[0]
-	s.agents = s.agents[1:]
-	return agent, true
+	worstIndex := -1
+	var worstScore float64
+	for i, entry := range s.entries {
+		entryScore := score(entry, connectedProxyIDs)
+		if worstIndex == -1 || entryScore > worstScore {
+			worstIndex = i
+			worstScore = entryScore
+		}
+	}
+
+	worst := s.entries[worstIndex]
+	s.entries = append(s.entries[:worstIndex], s.entries[worstIndex+1:]...)
+	return worst.agent, true
 }
 
 func (s *agentStore) last() (*Agent, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if len(s.agents) == 0 {
+	if len(s.entries) == 0 {
 		return nil, false
 	}
 
-	return s.agents[len(s.agents)-1], true
+	return s.entries[len(s.entries)-1].agent, true
+}
+
+// Stats returns a snapshot of the store for prometheus metrics.
+func (s *agentStore) Stats() AgentStoreStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := AgentStoreStats{Count: len(s.entries)}
+	for _, entry := range s.entries {
+		stats.TotalInflightSessions += entry.health.inflightSessions
+		if entry.health.consecutiveFailures == 0 {
+			stats.HealthyCount++
+		}
+	}
+	return stats
 }
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reversetunnel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentStorePickBestPrefersHealthyConnectedAgent(t *testing.T) {
+	s := newAgentStore()
+
+	stale := &Agent{}
+	healthy := &Agent{}
+
+	s.add(stale, "proxy-stale")
+	s.add(healthy, "proxy-healthy")
+
+	s.recordKeepaliveFailure(stale, time.Now())
+	s.recordKeepaliveFailure(stale, time.Now())
+	s.recordKeepalive(healthy, time.Millisecond)
+
+	best, ok := s.pickBest([]string{"proxy-healthy"})
+	require.True(t, ok)
+	require.Same(t, healthy, best)
+}
+
+func TestAgentStoreEvictWorstRemovesUnhealthiestAgent(t *testing.T) {
+	s := newAgentStore()
+
+	stale := &Agent{}
+	healthy := &Agent{}
+
+	s.add(stale, "proxy-stale")
+	s.add(healthy, "proxy-healthy")
+
+	s.recordKeepaliveFailure(stale, time.Now())
+	s.recordKeepalive(healthy, time.Millisecond)
+
+	evicted, ok := s.evictWorst([]string{"proxy-healthy"}, 1)
+	require.True(t, ok)
+	require.Same(t, stale, evicted)
+	require.Equal(t, 1, s.len())
+}
+
+func TestAgentStoreEvictWorstNoOpUnderCapacity(t *testing.T) {
+	s := newAgentStore()
+	s.add(&Agent{}, "proxy")
+
+	_, ok := s.evictWorst([]string{"proxy"}, 5)
+	require.False(t, ok)
+	require.Equal(t, 1, s.len())
+}
+
+func TestScorePenalizesUnhealthyAndDisconnectedAgents(t *testing.T) {
+	healthyConnected := &agentEntry{proxyID: "p1"}
+	unhealthyConnected := &agentEntry{proxyID: "p1", health: agentHealth{consecutiveFailures: 3, rtt: 50 * time.Millisecond}}
+	healthyDisconnected := &agentEntry{proxyID: "p2"}
+
+	connectedProxyIDs := []string{"p1"}
+
+	require.Less(t, score(healthyConnected, connectedProxyIDs), score(unhealthyConnected, connectedProxyIDs))
+	require.Less(t, score(healthyConnected, connectedProxyIDs), score(healthyDisconnected, connectedProxyIDs))
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uri
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Params holds named segment values captured by a Pattern match, e.g.
+// {"cluster": "foo"} for the ":cluster" segment.
+type Params map[string]string
+
+// Pattern matches ResourceURIs against a glob-style route, the way a router
+// matches request paths against registered routes. A plain segment must
+// match exactly, "*" matches any single segment, ":name" captures a single
+// segment under the given name, and a trailing "**" matches the rest of the
+// path, however many segments that is (including none).
+//
+// Pattern lets callers like RBAC checks and tshd event subscriptions ask
+// "does this URI belong to cluster X" or "is it any DB under leaf Y" without
+// string-prefixing or reparsing ResourceURI.Path themselves.
+type Pattern struct {
+	segments []string
+}
+
+// NewPattern compiles path into a Pattern.
+func NewPattern(path string) Pattern {
+	return Pattern{segments: splitPath(path)}
+}
+
+// Matches reports whether uri matches the pattern. When it does, the second
+// return value is true and Params holds the values captured by any named
+// (":foo") segments.
+func (p Pattern) Matches(uri ResourceURI) (Params, bool) {
+	uriSegments := splitPath(uri.Path)
+	params := Params{}
+
+	for i, segment := range p.segments {
+		if segment == "**" {
+			return params, true
+		}
+
+		if i >= len(uriSegments) {
+			return nil, false
+		}
+
+		switch {
+		case segment == "*":
+			// Matches any single segment, nothing to capture.
+		case strings.HasPrefix(segment, ":"):
+			params[strings.TrimPrefix(segment, ":")] = unescapeSegment(uriSegments[i])
+		case segment != uriSegments[i]:
+			return nil, false
+		}
+	}
+
+	if len(uriSegments) != len(p.segments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// String returns the pattern's source path.
+func (p Pattern) String() string {
+	return "/" + strings.Join(p.segments, "/")
+}
+
+// PatternAllDBsInCluster matches any database directly owned by the given
+// root cluster (not one of its leaves).
+func PatternAllDBsInCluster(cluster string) Pattern {
+	return NewPattern(fmt.Sprintf("/clusters/%v/dbs/*", url.PathEscape(cluster)))
+}
+
+// PatternAllLeafResources matches any resource belonging to any leaf cluster
+// of the given root cluster.
+func PatternAllLeafResources(cluster string) Pattern {
+	return NewPattern(fmt.Sprintf("/clusters/%v/leaves/*/**", url.PathEscape(cluster)))
+}
+
+// PatternAllGatewaysInCluster matches any gateway URI belonging to the given
+// root cluster.
+func PatternAllGatewaysInCluster(cluster string) Pattern {
+	return NewPattern(fmt.Sprintf("/clusters/%v/gateways/*", url.PathEscape(cluster)))
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uri
+
+import (
+	"encoding/json"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/lib/teleterm/api/uri/uriv1"
+)
+
+// subResourceKinds holds the ResourceKinds that qualify a resource nested
+// under another one (e.g. a namespace within a kube cluster), as opposed to
+// the top-level kinds in resourceKindsByPathSegment.
+var subResourceKinds = map[ResourceKind]bool{
+	KindKubeNamespace: true,
+	KindKubePod:       true,
+	KindKubeContainer: true,
+	KindDBUser:        true,
+	KindDBName:        true,
+}
+
+// tailSegments returns the segments of the path that remain after the
+// "clusters/:cluster" prefix and, if present, "leaves/:leaf" have been
+// stripped off — the same segments Parse hands off to the resource-kind
+// switch. Scanning only this tail, rather than the whole path, keeps a
+// cluster or leaf name that happens to collide with a kind word (e.g. a
+// cluster literally named "servers") from being mistaken for the resource
+// segment.
+func (r ResourceURI) tailSegments() []string {
+	segments := splitPath(r.Path)
+	if len(segments) < 2 {
+		return nil
+	}
+	segments = segments[2:]
+	if len(segments) >= 2 && segments[0] == "leaves" {
+		segments = segments[2:]
+	}
+	return segments
+}
+
+// topKindAndName returns the kind and name of the top-level resource (the
+// first of server/kube/db/app/gateway/windows_desktop) addressed by the URI.
+func (r ResourceURI) topKindAndName() (ResourceKind, string) {
+	segments := r.tailSegments()
+	if len(segments) < 2 {
+		return "", ""
+	}
+	kind, ok := resourceKindsByPathSegment[segments[0]]
+	if !ok {
+		return "", ""
+	}
+	return kind, unescapeSegment(segments[1])
+}
+
+// subKindAndName returns the kind and name of the resource nested under the
+// top-level resource addressed by the URI (e.g. a kube namespace), if any.
+func (r ResourceURI) subKindAndName() (ResourceKind, string) {
+	kind := r.Kind()
+	if !subResourceKinds[kind] {
+		return "", ""
+	}
+	return kind, r.Name()
+}
+
+// nestingDepthBeyondTop returns how many kind/name segment pairs follow the
+// top-level resource segment in the path, e.g. 2 for a kube URI addressing a
+// pod ("kubes/k/namespaces/ns/pods/p"). The sub_kind/sub_name fields on
+// uriv1.ResourceURI can only carry a single such pair, so anything deeper
+// than 1 can't be represented without losing information.
+func (r ResourceURI) nestingDepthBeyondTop() int {
+	segments := r.tailSegments()
+	if len(segments) < 2 {
+		return 0
+	}
+	if _, ok := resourceKindsByPathSegment[segments[0]]; !ok {
+		return 0
+	}
+	return (len(segments) - 2) / 2
+}
+
+// ToProto converts the URI into its canonical protobuf representation, ready
+// to be sent across the tshd/Connect gRPC boundary without making the
+// receiver re-parse a bare string. It returns an error rather than silently
+// dropping information for URIs nested more than one level below the
+// top-level resource (e.g. a kube pod or container), since sub_kind/sub_name
+// can only carry a single level.
+func (r ResourceURI) ToProto() (*uriv1.ResourceURI, error) {
+	if depth := r.nestingDepthBeyondTop(); depth > 1 {
+		return nil, trace.BadParameter("resource URI %q is nested too deeply to represent as a ResourceURI message", r.Path)
+	}
+
+	kind, name := r.topKindAndName()
+	subKind, subName := r.subKindAndName()
+
+	return &uriv1.ResourceURI{
+		Cluster: r.GetRootClusterName(),
+		Leaf:    r.GetLeafClusterName(),
+		Kind:    string(kind),
+		Name:    name,
+		SubKind: string(subKind),
+		SubName: subName,
+	}, nil
+}
+
+// FromProto rebuilds a ResourceURI from its canonical protobuf
+// representation. It is the inverse of ToProto.
+//
+// The message only carries one level of nesting below the top-level
+// resource (sub_kind/sub_name); ToProto rejects URIs that need more than
+// that rather than producing a message FromProto would reconstruct
+// incorrectly.
+func FromProto(msg *uriv1.ResourceURI) (ResourceURI, error) {
+	if msg == nil || msg.GetCluster() == "" {
+		return ResourceURI{}, trace.BadParameter("missing cluster in ResourceURI message")
+	}
+
+	uri := NewClusterURI(msg.GetCluster())
+	if msg.GetLeaf() != "" {
+		uri = uri.AppendLeafCluster(msg.GetLeaf())
+	}
+
+	switch ResourceKind(msg.GetKind()) {
+	case "":
+		return uri, nil
+	case KindServer:
+		uri = uri.AppendServer(msg.GetName())
+	case KindApp:
+		uri = uri.AppendApp(msg.GetName())
+	case KindGateway:
+		uri = uri.AddGateway(msg.GetName())
+	case KindWindowsDesktop:
+		uri = uri.AppendWindowsDesktop(msg.GetName())
+	case KindKube:
+		uri = uri.AppendKube(msg.GetName())
+		switch ResourceKind(msg.GetSubKind()) {
+		case "":
+		case KindKubeNamespace:
+			uri = uri.AppendKubeNamespace(msg.GetSubName())
+		case KindKubePod:
+			uri = uri.AppendKubePod(msg.GetSubName())
+		case KindKubeContainer:
+			uri = uri.AppendKubeContainer(msg.GetSubName())
+		default:
+			return ResourceURI{}, trace.BadParameter("unsupported kube sub-kind %q", msg.GetSubKind())
+		}
+	case KindDB:
+		uri = uri.AppendDB(msg.GetName())
+		switch ResourceKind(msg.GetSubKind()) {
+		case "":
+		case KindDBUser:
+			uri = uri.AppendDBUser(msg.GetSubName())
+		case KindDBName:
+			uri = uri.AppendDBName(msg.GetSubName())
+		default:
+			return ResourceURI{}, trace.BadParameter("unsupported db sub-kind %q", msg.GetSubKind())
+		}
+	default:
+		return ResourceURI{}, trace.BadParameter("unsupported resource kind %q", msg.GetKind())
+	}
+
+	return uri, nil
+}
+
+// jsonResourceURI is the JSON-equivalent of uriv1.ResourceURI, keeping the
+// two representations in lockstep.
+type jsonResourceURI struct {
+	Cluster string `json:"cluster,omitempty"`
+	Leaf    string `json:"leaf,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	Name    string `json:"name,omitempty"`
+	SubKind string `json:"sub_kind,omitempty"`
+	SubName string `json:"sub_name,omitempty"`
+}
+
+// MarshalJSON marshals the URI into its canonical structured form rather
+// than the bare Path string, so JSON consumers don't have to re-parse it.
+func (r ResourceURI) MarshalJSON() ([]byte, error) {
+	msg, err := r.ToProto()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	data, err := json.Marshal(jsonResourceURI{
+		Cluster: msg.GetCluster(),
+		Leaf:    msg.GetLeaf(),
+		Kind:    msg.GetKind(),
+		Name:    msg.GetName(),
+		SubKind: msg.GetSubKind(),
+		SubName: msg.GetSubName(),
+	})
+	return data, trace.Wrap(err)
+}
+
+// UnmarshalJSON is the inverse of MarshalJSON.
+func (r *ResourceURI) UnmarshalJSON(data []byte) error {
+	var parsed jsonResourceURI
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return trace.Wrap(err)
+	}
+
+	uri, err := FromProto(&uriv1.ResourceURI{
+		Cluster: parsed.Cluster,
+		Leaf:    parsed.Leaf,
+		Kind:    parsed.Kind,
+		Name:    parsed.Name,
+		SubKind: parsed.SubKind,
+		SubName: parsed.SubName,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	*r = uri
+	return nil
+}
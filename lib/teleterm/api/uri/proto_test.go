@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uri
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToProtoRoundTrip(t *testing.T) {
+	kubeNamespace := NewClusterURI("foo").AppendKube("k").AppendKubeNamespace("ns")
+	msg, err := kubeNamespace.ToProto()
+	require.NoError(t, err)
+
+	roundTripped, err := FromProto(msg)
+	require.NoError(t, err)
+	require.Equal(t, kubeNamespace, roundTripped)
+}
+
+// TestToProtoClusterNameCollidingWithKindWord verifies that a cluster or
+// leaf cluster whose name happens to equal a resource-kind word (e.g.
+// "servers") doesn't get mistaken for the resource segment when scanning
+// for the top-level kind/name.
+func TestToProtoClusterNameCollidingWithKindWord(t *testing.T) {
+	uri, err := Parse("/clusters/dbs/servers/s")
+	require.NoError(t, err)
+	require.Equal(t, KindServer, uri.Kind())
+	require.Equal(t, "s", uri.Name())
+
+	msg, err := uri.ToProto()
+	require.NoError(t, err)
+	require.Equal(t, "dbs", msg.GetCluster())
+	require.Equal(t, string(KindServer), msg.GetKind())
+	require.Equal(t, "s", msg.GetName())
+
+	roundTripped, err := FromProto(msg)
+	require.NoError(t, err)
+	require.Equal(t, uri, roundTripped)
+
+	leafURI, err := Parse("/clusters/root/leaves/servers/servers/s")
+	require.NoError(t, err)
+	msg, err = leafURI.ToProto()
+	require.NoError(t, err)
+	require.Equal(t, "servers", msg.GetLeaf())
+	require.Equal(t, string(KindServer), msg.GetKind())
+	require.Equal(t, "s", msg.GetName())
+}
+
+// TestToProtoRejectsDeepNesting verifies that a URI nested more than one
+// level below its top-level resource (e.g. a pod within a namespace) is
+// rejected rather than silently truncated, since uriv1.ResourceURI's
+// sub_kind/sub_name can only carry a single level.
+func TestToProtoRejectsDeepNesting(t *testing.T) {
+	kubePod := NewClusterURI("foo").AppendKube("k").AppendKubeNamespace("ns").AppendKubePod("p")
+	_, err := kubePod.ToProto()
+	require.Error(t, err)
+
+	kubeContainer := kubePod.AppendKubeContainer("c")
+	_, err = kubeContainer.ToProto()
+	require.Error(t, err)
+}
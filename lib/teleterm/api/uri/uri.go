@@ -18,6 +18,8 @@ package uri
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/gravitational/trace"
 )
@@ -25,6 +27,61 @@ import (
 var pathClusters = NewPath("/clusters/:cluster/*")
 var pathLeafClusters = NewPath("/clusters/:cluster/leaves/:leaf/*")
 
+// ResourceKind identifies the kind of resource a ResourceURI points at. The
+// kind is addressed by the plural segment that immediately follows the
+// cluster (or leaf cluster) in the path, e.g. "servers" in
+// "/clusters/foo/servers/bar" is KindServer ("server"); see
+// resourceKindsByPathSegment.
+type ResourceKind string
+
+const (
+	// KindServer is an SSH server (node).
+	KindServer ResourceKind = "server"
+	// KindKube is a Kubernetes cluster.
+	KindKube ResourceKind = "kube"
+	// KindDB is a database.
+	KindDB ResourceKind = "db"
+	// KindApp is an application.
+	KindApp ResourceKind = "app"
+	// KindGateway is a local proxy (gateway) created by tsh/Connect.
+	KindGateway ResourceKind = "gateway"
+	// KindWindowsDesktop is a Windows desktop.
+	KindWindowsDesktop ResourceKind = "windows_desktop"
+	// KindKubeNamespace is a namespace within a Kubernetes cluster.
+	KindKubeNamespace ResourceKind = "kube_namespace"
+	// KindKubePod is a pod within a Kubernetes namespace.
+	KindKubePod ResourceKind = "kube_pod"
+	// KindKubeContainer is a container within a Kubernetes pod.
+	KindKubeContainer ResourceKind = "kube_container"
+	// KindDBUser is a database user addressed within a database.
+	KindDBUser ResourceKind = "db_user"
+	// KindDBName is a database name addressed within a database.
+	KindDBName ResourceKind = "db_name"
+)
+
+// resourceKindsByPathSegment maps the plural path segment used in a
+// ResourceURI to the ResourceKind it represents.
+var resourceKindsByPathSegment = map[string]ResourceKind{
+	"servers":          KindServer,
+	"kubes":            KindKube,
+	"dbs":              KindDB,
+	"apps":             KindApp,
+	"gateways":         KindGateway,
+	"windows_desktops": KindWindowsDesktop,
+}
+
+// subResourceKindsByPathSegment maps the path segment used to address a
+// resource nested within another resource (e.g. a namespace within a
+// Kubernetes cluster) to the ResourceKind it represents. These only ever
+// appear after one of the kinds in resourceKindsByPathSegment.
+var subResourceKindsByPathSegment = map[string]ResourceKind{
+	"namespaces": KindKubeNamespace,
+	"pods":       KindKubePod,
+	"containers": KindKubeContainer,
+	"users":      KindDBUser,
+	"names":      KindDBName,
+}
+
 // New creates an instance of ResourceURI
 func New(path string) ResourceURI {
 	return ResourceURI{
@@ -32,10 +89,169 @@ func New(path string) ResourceURI {
 	}
 }
 
+// Parse parses path into a ResourceURI, validating its shape along the way.
+// A valid path looks like:
+//
+//	/clusters/:cluster(/leaves/:leaf)?(/servers|apps|gateways|windows_desktops/:name)?
+//	/clusters/:cluster(/leaves/:leaf)?(/kubes/:name(/namespaces/:ns(/pods/:pod(/containers/:container)?)?)?)?
+//	/clusters/:cluster(/leaves/:leaf)?(/dbs/:name(/users/:user)?(/names/:dbname)?)?
+//
+// Parse is meant to replace ad-hoc substring matching on ResourceURI.Path –
+// callers that need to know the kind or name of the resource a URI points at
+// should go through Parse and the typed accessors rather than poking at Path
+// directly.
+func Parse(path string) (ResourceURI, error) {
+	segments := splitPath(path)
+	if len(segments) < 2 || segments[0] != "clusters" {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: missing root cluster", path)
+	}
+	if segments[1] == "" {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: empty root cluster name", path)
+	}
+	rootClusterName, err := url.PathUnescape(segments[1])
+	if err != nil {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: %v", path, err)
+	}
+
+	uri := NewClusterURI(rootClusterName)
+	segments = segments[2:]
+
+	if len(segments) >= 2 && segments[0] == "leaves" {
+		if segments[1] == "" {
+			return ResourceURI{}, trace.BadParameter("invalid resource URI %q: empty leaf cluster name", path)
+		}
+		leafClusterName, err := url.PathUnescape(segments[1])
+		if err != nil {
+			return ResourceURI{}, trace.BadParameter("invalid resource URI %q: %v", path, err)
+		}
+		uri = uri.AppendLeafCluster(leafClusterName)
+		segments = segments[2:]
+	}
+
+	if len(segments) == 0 {
+		return uri, nil
+	}
+
+	if len(segments) < 2 {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: malformed resource segment", path)
+	}
+
+	kind, ok := resourceKindsByPathSegment[segments[0]]
+	if !ok {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: unknown resource kind %q", path, segments[0])
+	}
+	if segments[1] == "" {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: empty %v name", path, segments[0])
+	}
+	name, err := url.PathUnescape(segments[1])
+	if err != nil {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: %v", path, err)
+	}
+
+	switch kind {
+	case KindServer:
+		uri = uri.AppendServer(name)
+	case KindKube:
+		uri = uri.AppendKube(name)
+		uri, err = parseKubeSubResources(uri, path, segments[2:])
+	case KindDB:
+		uri = uri.AppendDB(name)
+		uri, err = parseDBSubResources(uri, path, segments[2:])
+	case KindApp:
+		uri = uri.AppendApp(name)
+	case KindGateway:
+		uri = uri.AddGateway(name)
+	case KindWindowsDesktop:
+		uri = uri.AppendWindowsDesktop(name)
+	}
+	if err != nil {
+		return ResourceURI{}, trace.Wrap(err)
+	}
+	switch kind {
+	case KindServer, KindApp, KindGateway, KindWindowsDesktop:
+		if len(segments) > 2 {
+			return ResourceURI{}, trace.BadParameter("invalid resource URI %q: unexpected trailing segments", path)
+		}
+	}
+
+	return uri, nil
+}
+
+// parseKubeSubResources consumes the optional namespace/pod/container
+// segments that may follow a kube segment in a path.
+func parseKubeSubResources(uri ResourceURI, path string, segments []string) (ResourceURI, error) {
+	for _, marker := range []struct {
+		segment string
+		append  func(ResourceURI, string) ResourceURI
+	}{
+		{"namespaces", ResourceURI.AppendKubeNamespace},
+		{"pods", ResourceURI.AppendKubePod},
+		{"containers", ResourceURI.AppendKubeContainer},
+	} {
+		if len(segments) == 0 {
+			break
+		}
+		if len(segments) < 2 || segments[0] != marker.segment {
+			return ResourceURI{}, trace.BadParameter("invalid resource URI %q: malformed kube sub-resource segment", path)
+		}
+		name, err := url.PathUnescape(segments[1])
+		if err != nil {
+			return ResourceURI{}, trace.BadParameter("invalid resource URI %q: %v", path, err)
+		}
+		uri = marker.append(uri, name)
+		segments = segments[2:]
+	}
+	if len(segments) != 0 {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: malformed kube sub-resource segment", path)
+	}
+	return uri, nil
+}
+
+// parseDBSubResources consumes the optional user/name segments that may
+// follow a db segment in a path.
+func parseDBSubResources(uri ResourceURI, path string, segments []string) (ResourceURI, error) {
+	for _, marker := range []struct {
+		segment string
+		append  func(ResourceURI, string) ResourceURI
+	}{
+		{"users", ResourceURI.AppendDBUser},
+		{"names", ResourceURI.AppendDBName},
+	} {
+		if len(segments) == 0 || segments[0] != marker.segment {
+			continue
+		}
+		if len(segments) < 2 {
+			return ResourceURI{}, trace.BadParameter("invalid resource URI %q: malformed db sub-resource segment", path)
+		}
+		name, err := url.PathUnescape(segments[1])
+		if err != nil {
+			return ResourceURI{}, trace.BadParameter("invalid resource URI %q: %v", path, err)
+		}
+		uri = marker.append(uri, name)
+		segments = segments[2:]
+	}
+	if len(segments) != 0 {
+		return ResourceURI{}, trace.BadParameter("invalid resource URI %q: malformed db sub-resource segment", path)
+	}
+	return uri, nil
+}
+
+// splitPath splits a URI path into its non-empty segments, e.g.
+// "/clusters/foo/" becomes ["clusters", "foo"].
+func splitPath(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
 // NewClusterURI creates a new cluster URI for given cluster name
 func NewClusterURI(clusterName string) ResourceURI {
 	return ResourceURI{
-		Path: fmt.Sprintf("/clusters/%v", clusterName),
+		Path: fmt.Sprintf("/clusters/%v", url.PathEscape(clusterName)),
 	}
 }
 
@@ -60,7 +276,7 @@ func ParseClusterURI(path string) (ResourceURI, error) {
 // NewGatewayURI creates a gateway URI for a given ID
 func NewGatewayURI(id string) ResourceURI {
 	return ResourceURI{
-		Path: fmt.Sprintf("/gateways/%v", id),
+		Path: fmt.Sprintf("/gateways/%v", url.PathEscape(id)),
 	}
 }
 
@@ -76,7 +292,7 @@ func (r ResourceURI) GetRootClusterName() string {
 		return ""
 	}
 
-	return result.Params["cluster"]
+	return unescapeSegment(result.Params["cluster"])
 }
 
 // GetLeafClusterName returns leaf cluster name
@@ -86,42 +302,207 @@ func (r ResourceURI) GetLeafClusterName() string {
 		return ""
 	}
 
-	return result.Params["leaf"]
+	return unescapeSegment(result.Params["leaf"])
+}
+
+// unescapeSegment decodes a percent-encoded path segment, falling back to the
+// raw value if it isn't valid percent-encoding (e.g. an older, unencoded
+// URI).
+func unescapeSegment(segment string) string {
+	decoded, err := url.PathUnescape(segment)
+	if err != nil {
+		return segment
+	}
+	return decoded
+}
+
+// IsLeaf returns true if the URI points at a resource within a leaf cluster.
+func (r ResourceURI) IsLeaf() bool {
+	return r.GetLeafClusterName() != ""
+}
+
+// IsGateway returns true if the URI points at a gateway.
+func (r ResourceURI) IsGateway() bool {
+	return r.Kind() == KindGateway
+}
+
+// Kind returns the kind of the deepest resource the URI points at, e.g. a URI
+// for a pod within a namespace within a kube cluster returns KindKubePod. It
+// returns an empty ResourceKind if the URI only identifies a cluster.
+func (r ResourceURI) Kind() ResourceKind {
+	segments := splitPath(r.Path)
+	if len(segments) < 2 {
+		return ""
+	}
+
+	last := len(segments) - 1
+	if kind, ok := subResourceKindsByPathSegment[segments[last-1]]; ok {
+		return kind
+	}
+	if kind, ok := resourceKindsByPathSegment[segments[last-1]]; ok {
+		return kind
+	}
+
+	return ""
+}
+
+// Name returns the name of the resource the URI points at. It returns an
+// empty string if the URI only identifies a cluster.
+func (r ResourceURI) Name() string {
+	if r.Kind() == "" {
+		return ""
+	}
+
+	segments := splitPath(r.Path)
+	return unescapeSegment(segments[len(segments)-1])
+}
+
+// GetKubeName returns the name of the Kubernetes cluster addressed by the
+// URI, or an empty string if the URI doesn't address one.
+func (r ResourceURI) GetKubeName() string {
+	return segmentAfter(r.Path, "kubes")
+}
+
+// GetKubeNamespace returns the Kubernetes namespace addressed by the URI, or
+// an empty string if the URI doesn't address one.
+func (r ResourceURI) GetKubeNamespace() string {
+	return segmentAfter(r.Path, "namespaces")
+}
+
+// GetKubePod returns the Kubernetes pod addressed by the URI, or an empty
+// string if the URI doesn't address one.
+func (r ResourceURI) GetKubePod() string {
+	return segmentAfter(r.Path, "pods")
+}
+
+// GetKubeContainer returns the Kubernetes container addressed by the URI, or
+// an empty string if the URI doesn't address one.
+func (r ResourceURI) GetKubeContainer() string {
+	return segmentAfter(r.Path, "containers")
+}
+
+// GetDBUser returns the database user addressed by the URI, or an empty
+// string if the URI doesn't address one.
+func (r ResourceURI) GetDBUser() string {
+	return segmentAfter(r.Path, "users")
+}
+
+// GetDBName returns the database name addressed by the URI, or an empty
+// string if the URI doesn't address one.
+func (r ResourceURI) GetDBName() string {
+	return segmentAfter(r.Path, "names")
+}
+
+// segmentAfter returns the decoded name paired with the first kind segment
+// equal to marker, or an empty string if marker isn't present. Only the
+// kind/name pairs after the cluster (and leaf cluster) are scanned, so a
+// cluster or resource whose name equals marker isn't mistaken for it.
+func segmentAfter(path string, marker string) string {
+	segments := ResourceURI{Path: path}.tailSegments()
+	for i := 0; i+1 < len(segments); i += 2 {
+		if segments[i] == marker {
+			return unescapeSegment(segments[i+1])
+		}
+	}
+	return ""
+}
+
+// Parent returns the URI of the resource one level up from this one, e.g. the
+// parent of a server URI is the cluster (or leaf cluster) URI it belongs to.
+// Parent returns the cluster URI unchanged if it has no parent.
+func (r ResourceURI) Parent() ResourceURI {
+	if r.Kind() != "" {
+		segments := splitPath(r.Path)
+		return New("/" + strings.Join(segments[:len(segments)-2], "/"))
+	}
+
+	if leaf := r.GetLeafClusterName(); leaf != "" {
+		return NewClusterURI(r.GetRootClusterName())
+	}
+
+	return r
+}
+
+// Equals returns true if the two URIs point at the same resource.
+func (r ResourceURI) Equals(other ResourceURI) bool {
+	return r.Path == other.Path
+}
+
+// HasPrefix returns true if the URI's path starts with the given URI's path,
+// e.g. a DB URI has the prefix of the cluster URI it belongs to.
+func (r ResourceURI) HasPrefix(other ResourceURI) bool {
+	return r.Path == other.Path || strings.HasPrefix(r.Path, other.Path+"/")
 }
 
 // AppendServer appends server segment to the URI
 func (r ResourceURI) AppendServer(id string) ResourceURI {
-	r.Path = fmt.Sprintf("%v/servers/%v", r.Path, id)
+	r.Path = fmt.Sprintf("%v/servers/%v", r.Path, url.PathEscape(id))
 	return r
 }
 
 // AppendLeafCluster appends leaf cluster segment to the URI
 func (r ResourceURI) AppendLeafCluster(name string) ResourceURI {
-	r.Path = fmt.Sprintf("%v/leaves/%v", r.Path, name)
+	r.Path = fmt.Sprintf("%v/leaves/%v", r.Path, url.PathEscape(name))
 	return r
 }
 
 // AppendKube appends kube segment to the URI
 func (r ResourceURI) AppendKube(name string) ResourceURI {
-	r.Path = fmt.Sprintf("%v/kubes/%v", r.Path, name)
+	r.Path = fmt.Sprintf("%v/kubes/%v", r.Path, url.PathEscape(name))
+	return r
+}
+
+// AppendKubeNamespace appends a Kubernetes namespace segment to the URI
+func (r ResourceURI) AppendKubeNamespace(name string) ResourceURI {
+	r.Path = fmt.Sprintf("%v/namespaces/%v", r.Path, url.PathEscape(name))
+	return r
+}
+
+// AppendKubePod appends a Kubernetes pod segment to the URI
+func (r ResourceURI) AppendKubePod(name string) ResourceURI {
+	r.Path = fmt.Sprintf("%v/pods/%v", r.Path, url.PathEscape(name))
+	return r
+}
+
+// AppendKubeContainer appends a Kubernetes container segment to the URI
+func (r ResourceURI) AppendKubeContainer(name string) ResourceURI {
+	r.Path = fmt.Sprintf("%v/containers/%v", r.Path, url.PathEscape(name))
+	return r
+}
+
+// AppendWindowsDesktop appends Windows desktop segment to the URI
+func (r ResourceURI) AppendWindowsDesktop(name string) ResourceURI {
+	r.Path = fmt.Sprintf("%v/windows_desktops/%v", r.Path, url.PathEscape(name))
 	return r
 }
 
 // AppendDB appends database segment to the URI
 func (r ResourceURI) AppendDB(name string) ResourceURI {
-	r.Path = fmt.Sprintf("%v/dbs/%v", r.Path, name)
+	r.Path = fmt.Sprintf("%v/dbs/%v", r.Path, url.PathEscape(name))
+	return r
+}
+
+// AppendDBUser appends a database user segment to the URI
+func (r ResourceURI) AppendDBUser(name string) ResourceURI {
+	r.Path = fmt.Sprintf("%v/users/%v", r.Path, url.PathEscape(name))
+	return r
+}
+
+// AppendDBName appends a database name segment to the URI
+func (r ResourceURI) AppendDBName(name string) ResourceURI {
+	r.Path = fmt.Sprintf("%v/names/%v", r.Path, url.PathEscape(name))
 	return r
 }
 
 // AddGateway appends gateway segment to the URI
 func (r ResourceURI) AddGateway(id string) ResourceURI {
-	r.Path = fmt.Sprintf("%v/gateways/%v", r.Path, id)
+	r.Path = fmt.Sprintf("%v/gateways/%v", r.Path, url.PathEscape(id))
 	return r
 }
 
 // AppendApp appends app segment to the URI
 func (r ResourceURI) AppendApp(name string) ResourceURI {
-	r.Path = fmt.Sprintf("%v/apps/%v", r.Path, name)
+	r.Path = fmt.Sprintf("%v/apps/%v", r.Path, url.PathEscape(name))
 	return r
 }
 
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uri
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestAppendRoundTripReservedCharacters verifies that names containing
+// characters that are reserved in a URI path (/, %, spaces, unicode) survive
+// a round trip through the Append*/New* constructors and back out through
+// Parse and the typed accessors unchanged.
+func TestAppendRoundTripReservedCharacters(t *testing.T) {
+	reservedNames := []string{
+		"foo/bar",
+		"100%",
+		"my cluster",
+		"日本語",
+		"a/b%c d",
+	}
+
+	for _, name := range reservedNames {
+		t.Run(name, func(t *testing.T) {
+			clusterURI := NewClusterURI(name)
+			require.Equal(t, name, clusterURI.GetRootClusterName())
+
+			parsedCluster, err := Parse(clusterURI.String())
+			require.NoError(t, err)
+			require.Equal(t, name, parsedCluster.GetRootClusterName())
+
+			leafURI := clusterURI.AppendLeafCluster(name)
+			require.Equal(t, name, leafURI.GetLeafClusterName())
+
+			parsedLeaf, err := Parse(leafURI.String())
+			require.NoError(t, err)
+			require.Equal(t, name, parsedLeaf.GetLeafClusterName())
+
+			serverURI := clusterURI.AppendServer(name)
+			require.Equal(t, name, serverURI.Name())
+
+			parsedServer, err := Parse(serverURI.String())
+			require.NoError(t, err)
+			require.Equal(t, name, parsedServer.Name())
+			require.Equal(t, KindServer, parsedServer.Kind())
+
+			kubeURI := clusterURI.AppendKube(name).AppendKubeNamespace(name).AppendKubePod(name).AppendKubeContainer(name)
+			require.Equal(t, name, kubeURI.GetKubeName())
+			require.Equal(t, name, kubeURI.GetKubeNamespace())
+			require.Equal(t, name, kubeURI.GetKubePod())
+			require.Equal(t, name, kubeURI.GetKubeContainer())
+
+			parsedKube, err := Parse(kubeURI.String())
+			require.NoError(t, err)
+			require.Equal(t, name, parsedKube.GetKubeName())
+			require.Equal(t, name, parsedKube.GetKubeNamespace())
+			require.Equal(t, name, parsedKube.GetKubePod())
+			require.Equal(t, name, parsedKube.GetKubeContainer())
+
+			dbURI := clusterURI.AppendDB(name).AppendDBUser(name).AppendDBName(name)
+			require.Equal(t, name, dbURI.Name())
+			require.Equal(t, name, dbURI.GetDBUser())
+			require.Equal(t, name, dbURI.GetDBName())
+
+			parsedDB, err := Parse(dbURI.String())
+			require.NoError(t, err)
+			require.Equal(t, name, parsedDB.Name())
+			require.Equal(t, name, parsedDB.GetDBUser())
+			require.Equal(t, name, parsedDB.GetDBName())
+
+			appURI := clusterURI.AppendApp(name)
+			require.Equal(t, name, appURI.Name())
+
+			parsedApp, err := Parse(appURI.String())
+			require.NoError(t, err)
+			require.Equal(t, name, parsedApp.Name())
+
+			gatewayURI := clusterURI.AddGateway(name)
+			require.Equal(t, name, gatewayURI.Name())
+
+			parsedGateway, err := Parse(gatewayURI.String())
+			require.NoError(t, err)
+			require.Equal(t, name, parsedGateway.Name())
+
+			windowsURI := clusterURI.AppendWindowsDesktop(name)
+			require.Equal(t, name, windowsURI.Name())
+
+			parsedWindows, err := Parse(windowsURI.String())
+			require.NoError(t, err)
+			require.Equal(t, name, parsedWindows.Name())
+		})
+	}
+}
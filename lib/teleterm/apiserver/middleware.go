@@ -18,13 +18,18 @@ package apiserver
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/gravitational/trace"
 
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // withErrorHandling is GRPC middleware that maps internal errors to proper GRPC error codes
@@ -49,37 +54,155 @@ func withErrorHandling(log logrus.FieldLogger) grpc.UnaryServerInterceptor {
 	}
 }
 
-// toGRPC converts error to GRPC-compatible error
+// toGRPC converts error to a GRPC-compatible error, attaching structured
+// google.rpc error details derived from the trace error's kind and fields so
+// that a client doesn't have to parse them back out of a bare message.
 func toGRPC(err error) error {
 	if err == nil {
 		return nil
 	}
-	message := getUserMessage(err)
-	if trace.IsNotFound(err) {
-		return status.Errorf(codes.NotFound, message)
+
+	st := status.New(grpcCode(err), getUserMessage(err))
+	if withDetails, detailErr := st.WithDetails(errorDetails(err)...); detailErr == nil {
+		st = withDetails
 	}
-	if trace.IsAlreadyExists(err) {
-		return status.Errorf(codes.AlreadyExists, message)
+
+	return st.Err()
+}
+
+// grpcCode maps a trace error kind to the GRPC status code a client expects.
+func grpcCode(err error) codes.Code {
+	switch {
+	case trace.IsNotFound(err):
+		return codes.NotFound
+	case trace.IsAlreadyExists(err):
+		return codes.AlreadyExists
+	case trace.IsAccessDenied(err):
+		return codes.PermissionDenied
+	case trace.IsCompareFailed(err):
+		return codes.FailedPrecondition
+	case trace.IsBadParameter(err), trace.IsOAuth2(err):
+		return codes.InvalidArgument
+	case trace.IsLimitExceeded(err):
+		return codes.ResourceExhausted
+	case trace.IsConnectionProblem(err):
+		return codes.Unavailable
+	case trace.IsNotImplemented(err):
+		return codes.Unimplemented
+	default:
+		return codes.Unknown
 	}
-	if trace.IsAccessDenied(err) {
-		return status.Errorf(codes.PermissionDenied, message)
+}
+
+// errorDetails builds the google.rpc error detail messages appropriate for
+// err's trace kind. These unlock precise, programmatic handling on the
+// client (e.g. backing off by RetryInfo.RetryDelay on Unavailable) instead
+// of pattern-matching the error message.
+func errorDetails(err error) []proto.Message {
+	switch {
+	case trace.IsAccessDenied(err):
+		return []proto.Message{&errdetails.ErrorInfo{Reason: "ACCESS_DENIED"}}
+	case trace.IsBadParameter(err):
+		if violations := fieldViolations(err); len(violations) > 0 {
+			return []proto.Message{&errdetails.BadRequest{FieldViolations: violations}}
+		}
+	case trace.IsLimitExceeded(err):
+		return []proto.Message{&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{
+				{Subject: "request", Description: getUserMessage(err)},
+			},
+		}}
+	case trace.IsConnectionProblem(err):
+		return []proto.Message{&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(time.Second),
+		}}
+	case trace.IsCompareFailed(err):
+		return []proto.Message{&errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{
+				{Type: "compare-and-swap", Description: getUserMessage(err)},
+			},
+		}}
 	}
-	if trace.IsCompareFailed(err) {
-		return status.Errorf(codes.FailedPrecondition, message)
+
+	return nil
+}
+
+// fieldViolations converts the structured fields attached to a trace error
+// (err.AddField(...)) into BadRequest.FieldViolations.
+func fieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	fielder, ok := err.(interface{ GetFields() trace.Fields })
+	if !ok {
+		return nil
 	}
-	if trace.IsBadParameter(err) || trace.IsOAuth2(err) {
-		return status.Errorf(codes.InvalidArgument, message)
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for field, value := range fielder.GetFields() {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fmt.Sprintf("%v", value),
+		})
 	}
-	if trace.IsLimitExceeded(err) {
-		return status.Errorf(codes.ResourceExhausted, message)
+	return violations
+}
+
+// FromGRPC reconstructs a typed trace.* error from a GRPC error produced by
+// toGRPC, reading back the google.rpc error details attached to it. It's the
+// client-side counterpart of toGRPC: a Go client sees the same error kind
+// (and, where available, the same field-level context) the server produced,
+// rather than a bare status with a message.
+func FromGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
 	}
-	if trace.IsConnectionProblem(err) {
-		return status.Errorf(codes.Unavailable, message)
+	message := st.Message()
+
+	var traceErr error
+	switch st.Code() {
+	case codes.NotFound:
+		traceErr = trace.NotFound(message)
+	case codes.AlreadyExists:
+		traceErr = trace.AlreadyExists(message)
+	case codes.PermissionDenied:
+		traceErr = trace.AccessDenied(message)
+	case codes.FailedPrecondition:
+		traceErr = trace.CompareFailed(message)
+	case codes.InvalidArgument:
+		traceErr = trace.BadParameter(message)
+	case codes.ResourceExhausted:
+		traceErr = trace.LimitExceeded(message)
+	case codes.Unavailable:
+		traceErr = trace.ConnectionProblem(err, message)
+	case codes.Unimplemented:
+		traceErr = trace.NotImplemented(message)
+	default:
+		return err
 	}
-	if trace.IsNotImplemented(err) {
-		return status.Errorf(codes.Unimplemented, message)
+
+	if traced, ok := traceErr.(*trace.TraceErr); ok {
+		for _, detail := range st.Details() {
+			switch detail := detail.(type) {
+			case *errdetails.BadRequest:
+				for _, violation := range detail.GetFieldViolations() {
+					traced.AddField(violation.GetField(), violation.GetDescription())
+				}
+			case *errdetails.RetryInfo:
+				if delay := detail.GetRetryDelay(); delay != nil {
+					traced.AddField("retry_delay", delay.AsDuration())
+				}
+			case *errdetails.QuotaFailure:
+				for _, violation := range detail.GetViolations() {
+					traced.AddField(violation.GetSubject(), violation.GetDescription())
+				}
+			}
+		}
 	}
-	return status.Errorf(codes.Unknown, message)
+
+	return traceErr
 }
 
 // getUserMessage returns the first (rather than the last) user error message from the stack
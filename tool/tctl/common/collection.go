@@ -17,6 +17,7 @@ limitations under the License.
 package common
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -118,6 +119,23 @@ func printNodeLabels(labels types.Labels) string {
 
 type serverCollection struct {
 	servers []types.Server
+	// pager, if set, lets this collection stream its servers page by page
+	// instead of requiring the caller to fetch them all up front into
+	// servers. Set it via newPagedServerCollection for `tctl get nodes`,
+	// whose GetNodes call can return tens of thousands of heartbeats.
+	pager PageFetcher
+}
+
+// newPagedServerCollection builds a serverCollection backed by fetch instead
+// of a pre-fetched slice, so WriteCollection's json/yaml output can stream
+// nodes straight from the auth client's paginated call rather than buffering
+// every node in memory first.
+func newPagedServerCollection(fetch PageFetcher) *serverCollection {
+	return &serverCollection{pager: fetch}
+}
+
+func (s *serverCollection) pageFetcher() (PageFetcher, bool) {
+	return s.pager, s.pager != nil
 }
 
 func (s *serverCollection) resources() (r []types.Resource) {
@@ -420,17 +438,57 @@ func formatLastHeartbeat(t time.Time) string {
 	return apiutils.HumanTimeFormat(t)
 }
 
-func writeJSON(c ResourceCollection, w io.Writer) error {
-	data, err := json.MarshalIndent(c.resources(), "", "    ")
+// writeJSONPath evaluates a kubectl-style JSONPath expression against each
+// of c's resources and streams the results to w, one per line.
+func writeJSONPath(c ResourceCollection, expr string, w io.Writer) error {
+	tw, err := NewJSONPathWriter(expr)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	_, err = w.Write(data)
-	return trace.Wrap(err)
+	return trace.Wrap(tw.Write(c, w))
+}
+
+// writeGoTemplate evaluates a text/template expression against each of c's
+// resources and streams the results to w, one per line.
+func writeGoTemplate(c ResourceCollection, expr string, w io.Writer) error {
+	tw, err := NewGoTemplateWriter(expr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(tw.Write(c, w))
 }
 
-func writeYAML(c ResourceCollection, w io.Writer) error {
-	return utils.WriteYAML(w, c.resources())
+const (
+	jsonPathPrefix   = "jsonpath="
+	goTemplatePrefix = "go-template="
+)
+
+// WriteCollection is the single entry point `tctl get`'s `-o` flag dispatches
+// through. It covers every collection type, including singletons like
+// authPrefCollection/netConfigCollection that only ever hold one resource,
+// since it only depends on the ResourceCollection interface. json and yaml
+// are streamed through c's ResourceIterator rather than buffering the whole
+// resource list up front.
+//
+// format is the value passed to `-o`: "text" (the default), "json", "yaml",
+// "describe", or a `jsonpath=<expr>`/`go-template=<expr>` expression.
+func WriteCollection(ctx context.Context, format string, verbose bool, c ResourceCollection, w io.Writer) error {
+	switch {
+	case format == "" || format == "text":
+		return trace.Wrap(c.writeText(verbose, w))
+	case format == "json":
+		return trace.Wrap(writeJSONStream(ctx, iteratorFor(c), w))
+	case format == "yaml":
+		return trace.Wrap(writeYAMLStream(ctx, iteratorFor(c), w))
+	case format == "describe":
+		return trace.Wrap(writeDescribe(c, w))
+	case strings.HasPrefix(format, jsonPathPrefix):
+		return trace.Wrap(writeJSONPath(c, strings.TrimPrefix(format, jsonPathPrefix), w))
+	case strings.HasPrefix(format, goTemplatePrefix):
+		return trace.Wrap(writeGoTemplate(c, strings.TrimPrefix(format, goTemplatePrefix), w))
+	default:
+		return trace.BadParameter("unsupported output format %q", format)
+	}
 }
 
 type semaphoreCollection struct {
@@ -0,0 +1,62 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+// TestWriteCollectionSingleton verifies that every output format is
+// reachable through WriteCollection for a singleton collection (one that
+// always holds exactly one resource), since those are the case the
+// per-type table/JSON code paths are easiest to forget to update.
+func TestWriteCollectionSingleton(t *testing.T) {
+	c := &authPrefCollection{authPref: types.DefaultAuthPreference()}
+
+	formats := []string{"text", "json", "yaml", "describe", "jsonpath={.Kind}", "go-template={{.Kind}}"}
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteCollection(context.Background(), format, false, c, &buf)
+			require.NoError(t, err)
+			require.NotEmpty(t, buf.String())
+		})
+	}
+}
+
+func TestDescribeCollection(t *testing.T) {
+	c := &authPrefCollection{authPref: types.DefaultAuthPreference()}
+
+	var buf bytes.Buffer
+	err := DescribeCollection(c, &buf)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "Name:")
+}
+
+func TestWriteCollectionUnsupportedFormat(t *testing.T) {
+	c := &authPrefCollection{authPref: types.DefaultAuthPreference()}
+
+	var buf bytes.Buffer
+	err := WriteCollection(context.Background(), "xml", false, c, &buf)
+	require.Error(t, err)
+}
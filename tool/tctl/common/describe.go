@@ -0,0 +1,194 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// Describer is implemented by collections that want to render a detailed,
+// multi-section, per-resource report – analogous to `kubectl describe` –
+// instead of the reflective default. It's exposed as `tctl describe
+// <kind>/<name>`, which takes kind and name together as a single argument,
+// and as `-o describe` on `tctl get`.
+type Describer interface {
+	Describe(w io.Writer) error
+}
+
+// DescribeCollection renders c in human-readable, `kubectl describe`-style
+// form. It's the entry point both `tctl describe <kind>/<name>` and `-o
+// describe` on `tctl get` (via WriteCollection) call into.
+func DescribeCollection(c ResourceCollection, w io.Writer) error {
+	return trace.Wrap(writeDescribe(c, w))
+}
+
+// writeDescribe renders c in human-readable, `kubectl describe`-style form.
+// Collections that implement Describer are given full control over their
+// output; everything else falls back to describeDefault.
+func writeDescribe(c ResourceCollection, w io.Writer) error {
+	if d, ok := c.(Describer); ok {
+		return trace.Wrap(d.Describe(w))
+	}
+	return trace.Wrap(describeDefault(c, w))
+}
+
+// describeDefault prints name, labels (grouped by namespace prefix such as
+// "teleport.dev/") and the whole resource as indented YAML under a "Spec:"
+// header, for collections that don't need anything fancier.
+func describeDefault(c ResourceCollection, w io.Writer) error {
+	for i, resource := range c.resources() {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintf(w, "Name:\t%v\n", resource.GetName())
+		fmt.Fprintf(w, "Kind:\t%v\n", resource.GetKind())
+
+		if labeled, ok := resource.(interface{ GetAllLabels() map[string]string }); ok {
+			writeLabelsByNamespace(w, labeled.GetAllLabels())
+		}
+
+		fmt.Fprintln(w, "Spec:")
+		var buf bytes.Buffer
+		if err := utils.WriteYAML(&buf, resource); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Fprint(w, indentLines(buf.String(), "  "))
+	}
+	return nil
+}
+
+// writeLabelsByNamespace prints labels grouped by the namespace prefix
+// before the last "/" in their key (e.g. "teleport.dev/") with unprefixed
+// labels grouped under "(none)".
+func writeLabelsByNamespace(w io.Writer, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	const noNamespace = "(none)"
+	byNamespace := make(map[string]map[string]string)
+	for key, value := range labels {
+		namespace := noNamespace
+		if i := strings.LastIndex(key, "/"); i != -1 {
+			namespace = key[:i+1]
+			key = key[i+1:]
+		}
+		if byNamespace[namespace] == nil {
+			byNamespace[namespace] = make(map[string]string)
+		}
+		byNamespace[namespace][key] = value
+	}
+
+	namespaces := make([]string, 0, len(byNamespace))
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	fmt.Fprintln(w, "Labels:")
+	for _, namespace := range namespaces {
+		fmt.Fprintf(w, "  %v\n", namespace)
+		keys := make([]string, 0, len(byNamespace[namespace]))
+		for key := range byNamespace[namespace] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "    %v=%v\n", key, byNamespace[namespace][key])
+		}
+	}
+}
+
+// indentLines prefixes every line of s with prefix.
+func indentLines(s string, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (r *roleCollection) Describe(w io.Writer) error {
+	for i, role := range r.roles {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Name:\t%v\n", role.GetName())
+		writeLabelsByNamespace(w, role.GetMetadata().Labels)
+		fmt.Fprintf(w, "Logins (allow):\t%v\n", strings.Join(role.GetLogins(types.Allow), ", "))
+		fmt.Fprintf(w, "Node labels (allow):\t%v\n", printNodeLabels(role.GetNodeLabels(types.Allow)))
+		fmt.Fprintln(w, "Rules (allow):")
+		for _, rule := range role.GetRules(types.Allow) {
+			fmt.Fprintf(w, "  - resources: %v\n", strings.Join(rule.Resources, ", "))
+			fmt.Fprintf(w, "    verbs:     %v\n", strings.Join(rule.Verbs, ", "))
+		}
+	}
+	return nil
+}
+
+func (c *githubCollection) Describe(w io.Writer) error {
+	for i, conn := range c.connectors {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Name:\t%v\n", conn.GetName())
+		fmt.Fprintln(w, "Teams to logins:")
+		for _, mapping := range conn.GetTeamsToLogins() {
+			fmt.Fprintf(w, "  @%v/%v:\t%v\n", mapping.Organization, mapping.Team, strings.Join(mapping.Logins, ", "))
+		}
+	}
+	return nil
+}
+
+func (c *netRestrictionsCollection) Describe(w io.Writer) error {
+	fmt.Fprintln(w, "Allow:")
+	for _, a := range c.netRestricts.GetAllow() {
+		fmt.Fprintf(w, "  %v\n", a.CIDR)
+	}
+	fmt.Fprintln(w, "Deny:")
+	for _, d := range c.netRestricts.GetDeny() {
+		fmt.Fprintf(w, "  %v\n", d.CIDR)
+	}
+	return nil
+}
+
+func (c *semaphoreCollection) Describe(w io.Writer) error {
+	for i, sem := range c.sems {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Name:\t%v\n", sem.GetName())
+		fmt.Fprintf(w, "Kind:\t%v\n", sem.GetSubKind())
+		fmt.Fprintln(w, "Leases:")
+		for _, ref := range sem.LeaseRefs() {
+			fmt.Fprintf(w, "  - id:      %v\n", ref.LeaseID)
+			fmt.Fprintf(w, "    holder:  %v\n", ref.Holder)
+			fmt.Fprintf(w, "    expires: %v\n", ref.Expires)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apiutils "github.com/gravitational/teleport/api/utils"
+)
+
+// templateFuncMap is made available to `-o go-template` expressions, on top
+// of the text/template builtins.
+var templateFuncMap = template.FuncMap{
+	"label":     templateLabel,
+	"join":      templateJoin,
+	"humanTime": templateHumanTime,
+}
+
+// templateLabel looks up a label on any resource that exposes
+// GetAllLabels() map[string]string, e.g. `{{label "env" .}}`.
+func templateLabel(key string, resource interface{}) string {
+	method := reflect.ValueOf(resource).MethodByName("GetAllLabels")
+	if !method.IsValid() {
+		return ""
+	}
+	results := method.Call(nil)
+	if len(results) != 1 {
+		return ""
+	}
+	labels, ok := results[0].Interface().(map[string]string)
+	if !ok {
+		return ""
+	}
+	return labels[key]
+}
+
+func templateJoin(sep string, items []string) string {
+	return strings.Join(items, sep)
+}
+
+func templateHumanTime(t time.Time) string {
+	return apiutils.HumanTimeFormat(t)
+}
+
+// TemplatedWriter renders a ResourceCollection with either a `-o
+// jsonpath=...` or a `-o go-template=...` expression, evaluating it against
+// each element of resources() and streaming the result to a writer. It lets
+// `tctl get` be scripted against without post-processing JSON with jq.
+type TemplatedWriter struct {
+	jsonPath *jsonPath
+	tmpl     *template.Template
+}
+
+// NewJSONPathWriter compiles a kubectl-style JSONPath expression.
+func NewJSONPathWriter(expr string) (*TemplatedWriter, error) {
+	path, err := newJSONPath(expr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &TemplatedWriter{jsonPath: path}, nil
+}
+
+// NewGoTemplateWriter compiles a text/template expression.
+func NewGoTemplateWriter(expr string) (*TemplatedWriter, error) {
+	tmpl, err := template.New("tctl-get").Funcs(templateFuncMap).Parse(expr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &TemplatedWriter{tmpl: tmpl}, nil
+}
+
+// Write evaluates the compiled expression against every resource in c and
+// writes one line of output per resource to w.
+func (tw *TemplatedWriter) Write(c ResourceCollection, w io.Writer) error {
+	for _, resource := range c.resources() {
+		var line string
+		var err error
+
+		if tw.tmpl != nil {
+			line, err = tw.renderTemplate(resource)
+		} else {
+			line, err = tw.renderJSONPath(resource)
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (tw *TemplatedWriter) renderTemplate(resource interface{}) (string, error) {
+	var buf strings.Builder
+	if err := tw.tmpl.Execute(&buf, resource); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return buf.String(), nil
+}
+
+func (tw *TemplatedWriter) renderJSONPath(resource interface{}) (string, error) {
+	// Resources only expose their fields through JSON tags, so round-trip
+	// through encoding/json to get something jsonPath can navigate rather
+	// than reimplementing struct reflection.
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return "", trace.Wrap(err)
+	}
+
+	matches := tw.jsonPath.eval(root)
+	parts := make([]string, 0, len(matches))
+	for _, match := range matches {
+		parts = append(parts, fmt.Sprintf("%v", match))
+	}
+
+	return strings.Join(parts, " "), nil
+}
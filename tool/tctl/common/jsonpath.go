@@ -0,0 +1,255 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// jsonPath is a compiled kubectl-style JSONPath expression. It supports the
+// subset used in practice for `tctl get -o jsonpath=...`: field access
+// (.metadata.name), recursive descent (..spec.uri), index/slice ([0],
+// [0:2]) and a simple equality filter ([?(@.kind=="node")]).
+type jsonPath struct {
+	ops []pathOp
+}
+
+type pathOpKind int
+
+const (
+	opField pathOpKind = iota
+	opRecursive
+	opIndex
+	opSlice
+	opFilter
+)
+
+type pathOp struct {
+	kind pathOpKind
+	// field is the key looked up by opField/opRecursive and the field
+	// compared by opFilter.
+	field string
+	// index is used by opIndex.
+	index int
+	// from/to are used by opSlice.
+	from, to int
+	// value is the literal compared against by opFilter.
+	value string
+}
+
+// newJSONPath compiles expr into a jsonPath. expr may optionally be wrapped
+// in the kubectl-style braces, e.g. "{.metadata.name}".
+func newJSONPath(expr string) (*jsonPath, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "{")
+	expr = strings.TrimSuffix(expr, "}")
+
+	var ops []pathOp
+	for len(expr) > 0 {
+		switch {
+		case strings.HasPrefix(expr, ".."):
+			expr = expr[2:]
+			field, rest := consumeIdent(expr)
+			if field == "" {
+				return nil, trace.BadParameter("jsonpath: expected field name after '..' in %q", expr)
+			}
+			ops = append(ops, pathOp{kind: opRecursive, field: field})
+			expr = rest
+		case strings.HasPrefix(expr, "."):
+			expr = expr[1:]
+			field, rest := consumeIdent(expr)
+			if field == "" {
+				return nil, trace.BadParameter("jsonpath: expected field name after '.' in %q", expr)
+			}
+			ops = append(ops, pathOp{kind: opField, field: field})
+			expr = rest
+		case strings.HasPrefix(expr, "["):
+			end := strings.Index(expr, "]")
+			if end < 0 {
+				return nil, trace.BadParameter("jsonpath: unterminated '[' in %q", expr)
+			}
+			op, err := parseBracketOp(expr[1:end])
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			ops = append(ops, op)
+			expr = expr[end+1:]
+		default:
+			return nil, trace.BadParameter("jsonpath: unexpected character %q", expr[:1])
+		}
+	}
+
+	return &jsonPath{ops: ops}, nil
+}
+
+// consumeIdent reads a leading identifier (letters, digits, underscore) off
+// s, returning it and the remainder of s.
+func consumeIdent(s string) (string, string) {
+	i := 0
+	for i < len(s) && (isIdentByte(s[i])) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' ||
+		(b >= 'a' && b <= 'z') ||
+		(b >= 'A' && b <= 'Z') ||
+		(b >= '0' && b <= '9')
+}
+
+// parseBracketOp parses the contents of a single "[...]" segment.
+func parseBracketOp(s string) (pathOp, error) {
+	switch {
+	case strings.HasPrefix(s, "?(") && strings.HasSuffix(s, ")"):
+		return parseFilterOp(strings.TrimSuffix(strings.TrimPrefix(s, "?("), ")"))
+	case strings.Contains(s, ":"):
+		parts := strings.SplitN(s, ":", 2)
+		from, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return pathOp{}, trace.BadParameter("jsonpath: invalid slice start %q", parts[0])
+		}
+		to, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return pathOp{}, trace.BadParameter("jsonpath: invalid slice end %q", parts[1])
+		}
+		return pathOp{kind: opSlice, from: from, to: to}, nil
+	default:
+		index, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return pathOp{}, trace.BadParameter("jsonpath: invalid index %q", s)
+		}
+		return pathOp{kind: opIndex, index: index}, nil
+	}
+}
+
+// parseFilterOp parses a "@.field==value" predicate.
+func parseFilterOp(s string) (pathOp, error) {
+	s = strings.TrimSpace(s)
+	const prefix = "@."
+	if !strings.HasPrefix(s, prefix) {
+		return pathOp{}, trace.BadParameter("jsonpath: unsupported filter %q, expected @.field==value", s)
+	}
+	s = strings.TrimPrefix(s, prefix)
+
+	eq := strings.Index(s, "==")
+	if eq < 0 {
+		return pathOp{}, trace.BadParameter("jsonpath: unsupported filter %q, expected @.field==value", s)
+	}
+
+	field := strings.TrimSpace(s[:eq])
+	value := strings.TrimSpace(s[eq+2:])
+	value = strings.Trim(value, `"'`)
+
+	return pathOp{kind: opFilter, field: field, value: value}, nil
+}
+
+// eval evaluates the compiled path against root, returning every matched
+// leaf value in encounter order.
+func (p *jsonPath) eval(root interface{}) []interface{} {
+	current := []interface{}{root}
+	for _, op := range p.ops {
+		var next []interface{}
+		for _, value := range current {
+			next = append(next, applyOp(op, value)...)
+		}
+		current = next
+	}
+	return current
+}
+
+func applyOp(op pathOp, value interface{}) []interface{} {
+	switch op.kind {
+	case opField:
+		if m, ok := value.(map[string]interface{}); ok {
+			if v, ok := m[op.field]; ok {
+				return []interface{}{v}
+			}
+		}
+		return nil
+	case opRecursive:
+		var found []interface{}
+		collectRecursive(value, op.field, &found)
+		return found
+	case opIndex:
+		if s, ok := value.([]interface{}); ok && op.index >= 0 && op.index < len(s) {
+			return []interface{}{s[op.index]}
+		}
+		return nil
+	case opSlice:
+		s, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		from, to := op.from, op.to
+		if from < 0 {
+			from = 0
+		}
+		if to > len(s) {
+			to = len(s)
+		}
+		if from >= to {
+			return nil
+		}
+		out := make([]interface{}, 0, to-from)
+		for _, v := range s[from:to] {
+			out = append(out, v)
+		}
+		return out
+	case opFilter:
+		s, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		var out []interface{}
+		for _, elem := range s {
+			m, ok := elem.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if fmt.Sprintf("%v", m[op.field]) == op.value {
+				out = append(out, elem)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// collectRecursive walks value depth-first, appending to found every value
+// keyed by field at any depth.
+func collectRecursive(value interface{}, field string, found *[]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if match, ok := v[field]; ok {
+			*found = append(*found, match)
+		}
+		for _, child := range v {
+			collectRecursive(child, field, found)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectRecursive(child, field, found)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"os"
+
+	"github.com/gravitational/trace"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// ResourceCommand implements `tctl get` and `tctl describe`. It only owns
+// the `-o`/`--format` flag and the ref argument; fetching the
+// ResourceCollection a given ref refers to (which requires an auth client
+// connected to a live cluster) is left to the getCollection func TryRun is
+// called with, so this command can route its output through
+// WriteCollection/DescribeCollection without this package needing to depend
+// on the auth client package.
+type ResourceCommand struct {
+	getCmd      *kingpin.CmdClause
+	describeCmd *kingpin.CmdClause
+
+	ref     string
+	format  string
+	verbose bool
+}
+
+// Initialize registers `tctl get <ref>` and `tctl describe <ref>` with app.
+func (rc *ResourceCommand) Initialize(app *kingpin.Application) {
+	rc.getCmd = app.Command("get", "Print various Teleport resources.")
+	rc.getCmd.Arg("resource", "Resource type and optional name (e.g. \"nodes\" or \"nodes/node-name\")").Required().StringVar(&rc.ref)
+	rc.getCmd.Flag("format", "Output format: text, json, yaml, describe, jsonpath=<expr>, go-template=<expr>").Short('o').Default("text").StringVar(&rc.format)
+	rc.getCmd.Flag("verbose", "Print more fields").Short('v').BoolVar(&rc.verbose)
+
+	rc.describeCmd = app.Command("describe", "Print a detailed, human-oriented report of a single resource.")
+	rc.describeCmd.Arg("resource", "Resource type and name (e.g. \"node/node-name\")").Required().StringVar(&rc.ref)
+}
+
+// TryRun takes the resource collection for rc.ref (produced by getCollection,
+// which is given the ref in full so it can scope the fetch — and page it via
+// a PagedCollection where that's supported — instead of always fetching
+// every resource of that kind) and renders it to stdout. It reports false
+// if cmd isn't one of the commands this type registered.
+func (rc *ResourceCommand) TryRun(ctx context.Context, cmd string, getCollection func(ref string) (ResourceCollection, error)) (match bool, err error) {
+	switch cmd {
+	case rc.getCmd.FullCommand():
+		c, err := getCollection(rc.ref)
+		if err != nil {
+			return true, trace.Wrap(err)
+		}
+		return true, trace.Wrap(WriteCollection(ctx, rc.format, rc.verbose, c, os.Stdout))
+	case rc.describeCmd.FullCommand():
+		c, err := getCollection(rc.ref)
+		if err != nil {
+			return true, trace.Wrap(err)
+		}
+		return true, trace.Wrap(DescribeCollection(c, os.Stdout))
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+// captureStdout temporarily redirects os.Stdout to a pipe for the duration
+// of fn and returns everything written to it.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	old := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = old }()
+
+	fn()
+
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(bufio.NewReader(r))
+	require.NoError(t, err)
+	return string(out)
+}
+
+// TestResourceCommandGet verifies that `tctl get <ref> -o <format>` reaches
+// WriteCollection end to end through real kingpin flag parsing, i.e. that
+// the format switch added to WriteCollection is actually wired up rather
+// than only reachable from tests that call it directly.
+func TestResourceCommandGet(t *testing.T) {
+	app := kingpin.New("tctl", "")
+	var rc ResourceCommand
+	rc.Initialize(app)
+
+	cmd, err := app.Parse([]string{"get", "cluster_auth_preference", "-o", "json"})
+	require.NoError(t, err)
+
+	c := &authPrefCollection{authPref: types.DefaultAuthPreference()}
+	getCollection := func(ref string) (ResourceCollection, error) {
+		require.Equal(t, "cluster_auth_preference", ref)
+		return c, nil
+	}
+
+	var match bool
+	out := captureStdout(t, func() {
+		match, err = rc.TryRun(context.Background(), cmd, getCollection)
+	})
+	require.NoError(t, err)
+	require.True(t, match)
+	require.Contains(t, out, `"type"`)
+}
+
+// TestResourceCommandDescribe verifies that `tctl describe <ref>` reaches
+// DescribeCollection through the same real flag-parsing path, rather than
+// only being reachable from tests that call DescribeCollection directly.
+func TestResourceCommandDescribe(t *testing.T) {
+	app := kingpin.New("tctl", "")
+	var rc ResourceCommand
+	rc.Initialize(app)
+
+	cmd, err := app.Parse([]string{"describe", "cluster_auth_preference"})
+	require.NoError(t, err)
+
+	c := &authPrefCollection{authPref: types.DefaultAuthPreference()}
+	getCollection := func(ref string) (ResourceCollection, error) {
+		require.Equal(t, "cluster_auth_preference", ref)
+		return c, nil
+	}
+
+	var match bool
+	out := captureStdout(t, func() {
+		match, err = rc.TryRun(context.Background(), cmd, getCollection)
+	})
+	require.NoError(t, err)
+	require.True(t, match)
+	require.Contains(t, out, "Name:")
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// ResourceIterator yields resources one at a time instead of requiring the
+// caller to materialize the whole set, so `tctl get nodes/app_servers/...`
+// doesn't have to buffer tens of thousands of heartbeats in memory before
+// printing the first one. Next returns io.EOF once exhausted.
+type ResourceIterator interface {
+	Next(ctx context.Context) (types.Resource, error)
+}
+
+// sliceIterator adapts a pre-fetched []types.Resource to ResourceIterator.
+// It's the thin adapter ResourceCollection-based callers use until their
+// underlying auth client call is migrated to a paginated one.
+type sliceIterator struct {
+	resources []types.Resource
+	pos       int
+}
+
+// newSliceIterator wraps resources in a ResourceIterator.
+func newSliceIterator(resources []types.Resource) *sliceIterator {
+	return &sliceIterator{resources: resources}
+}
+
+func (it *sliceIterator) Next(_ context.Context) (types.Resource, error) {
+	if it.pos >= len(it.resources) {
+		return nil, io.EOF
+	}
+	resource := it.resources[it.pos]
+	it.pos++
+	return resource, nil
+}
+
+// PageFetcher fetches one page of resources starting at startKey (empty for
+// the first page), returning the page and the key to pass back in to fetch
+// the next one. nextKey is empty once the last page has been returned.
+type PageFetcher func(ctx context.Context, startKey string) (page []types.Resource, nextKey string, err error)
+
+// PagedCollection is implemented by collections whose underlying auth client
+// call already pages server-side (GetNodes, GetApplicationServers, ...).
+// iteratorFor prefers pageFetcher over resources() when it's present, so
+// `tctl get`'s json/yaml output can stream a large cluster's resources page
+// by page instead of holding the whole result set in memory at once.
+type PagedCollection interface {
+	ResourceCollection
+	pageFetcher() (fetch PageFetcher, ok bool)
+}
+
+// pagedIterator adapts a PageFetcher to ResourceIterator, holding only the
+// current page in memory rather than the whole result set.
+type pagedIterator struct {
+	fetch   PageFetcher
+	page    []types.Resource
+	pos     int
+	nextKey string
+	done    bool
+}
+
+// newPagedIterator wraps fetch in a ResourceIterator that pulls pages on
+// demand as Next drains the previous one.
+func newPagedIterator(fetch PageFetcher) *pagedIterator {
+	return &pagedIterator{fetch: fetch}
+}
+
+func (it *pagedIterator) Next(ctx context.Context) (types.Resource, error) {
+	for it.pos >= len(it.page) {
+		if it.done {
+			return nil, io.EOF
+		}
+
+		page, nextKey, err := it.fetch(ctx, it.nextKey)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		it.page, it.pos, it.nextKey = page, 0, nextKey
+		if nextKey == "" {
+			it.done = true
+		}
+	}
+
+	resource := it.page[it.pos]
+	it.pos++
+	return resource, nil
+}
+
+// iteratorFor returns a ResourceIterator over c's resources, used by
+// WriteCollection's json/yaml output so large result sets are streamed to
+// the writer one element at a time instead of held in memory as a slice
+// while being marshaled. Collections that implement PagedCollection are
+// streamed page by page straight from their auth client call; everything
+// else falls back to a sliceIterator over the already-materialized
+// resources() slice.
+func iteratorFor(c ResourceCollection) ResourceIterator {
+	if pc, ok := c.(PagedCollection); ok {
+		if fetch, ok := pc.pageFetcher(); ok {
+			return newPagedIterator(fetch)
+		}
+	}
+	return newSliceIterator(c.resources())
+}
+
+// writeJSONStream streams it as a JSON array, encoding one element at a time
+// instead of marshaling the whole slice up front.
+func writeJSONStream(ctx context.Context, it ResourceIterator, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return trace.Wrap(err)
+	}
+
+	enc := json.NewEncoder(w)
+	first := true
+	for {
+		resource, err := it.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		first = false
+
+		if err := enc.Encode(resource); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	_, err := io.WriteString(w, "]\n")
+	return trace.Wrap(err)
+}
+
+// writeYAMLStream streams it as a sequence of "---"-separated YAML
+// documents instead of marshaling the whole slice up front.
+func writeYAMLStream(ctx context.Context, it ResourceIterator, w io.Writer) error {
+	first := true
+	for {
+		resource, err := it.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+
+		if !first {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		first = false
+
+		if err := utils.WriteYAML(w, resource); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	return nil
+}
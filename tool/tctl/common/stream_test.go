@@ -0,0 +1,83 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestWriteCollectionStreamsJSONAndYAML(t *testing.T) {
+	server1, err := types.NewServer("node-a", types.KindNode, types.ServerSpecV2{})
+	require.NoError(t, err)
+	server2, err := types.NewServer("node-b", types.KindNode, types.ServerSpecV2{})
+	require.NoError(t, err)
+
+	c := &serverCollection{servers: []types.Server{server1, server2}}
+
+	var jsonBuf bytes.Buffer
+	require.NoError(t, WriteCollection(context.Background(), "json", false, c, &jsonBuf))
+	require.Contains(t, jsonBuf.String(), "node-a")
+	require.Contains(t, jsonBuf.String(), "node-b")
+
+	var yamlBuf bytes.Buffer
+	require.NoError(t, WriteCollection(context.Background(), "yaml", false, c, &yamlBuf))
+	require.Contains(t, yamlBuf.String(), "node-a")
+	require.Contains(t, yamlBuf.String(), "---")
+}
+
+// TestWriteCollectionStreamsPagedCollection verifies that a serverCollection
+// backed by a PageFetcher (built via newPagedServerCollection rather than a
+// pre-fetched servers slice) has its JSON output pulled from the fetcher:
+// every page's nodes are written, and each page is fetched exactly once, in
+// order, following the returned nextKey.
+func TestWriteCollectionStreamsPagedCollection(t *testing.T) {
+	server1, err := types.NewServer("node-a", types.KindNode, types.ServerSpecV2{})
+	require.NoError(t, err)
+	server2, err := types.NewServer("node-b", types.KindNode, types.ServerSpecV2{})
+	require.NoError(t, err)
+	server3, err := types.NewServer("node-c", types.KindNode, types.ServerSpecV2{})
+	require.NoError(t, err)
+
+	pages := map[string][]types.Server{
+		"":  {server1, server2},
+		"b": {server3},
+	}
+	nextKeys := map[string]string{"": "b", "b": ""}
+
+	var fetchedKeys []string
+	c := newPagedServerCollection(func(_ context.Context, startKey string) ([]types.Resource, string, error) {
+		fetchedKeys = append(fetchedKeys, startKey)
+		var page []types.Resource
+		for _, s := range pages[startKey] {
+			page = append(page, s)
+		}
+		return page, nextKeys[startKey], nil
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCollection(context.Background(), "json", false, c, &buf))
+	require.Contains(t, buf.String(), "node-a")
+	require.Contains(t, buf.String(), "node-b")
+	require.Contains(t, buf.String(), "node-c")
+	require.Equal(t, []string{"", "b"}, fetchedKeys)
+}